@@ -21,6 +21,15 @@ import (
 	"github.com/pborman/uuid"
 )
 
+// OpenServiceBroker - Implements the v2.14 Open Service Broker API surface.
+// https://github.com/openservicebrokerapi/servicebroker/blob/v2.14/spec.md
+//
+// The context.Context passed to Provision, Deprovision, Bind, Unbind and
+// Update carries the caller's OriginatingIdentity and request identity, if
+// the platform and the server handling the request supplied them. Use
+// OriginatingIdentityFromContext and RequestIdentityFromContext to read
+// them, e.g. to return ErrorForbidden based on the calling user rather than
+// only broker-level basic auth.
 type OpenServiceBroker interface {
 	Catalog() (*CatalogResponse, error)
 	Provision(uuid.UUID, *ProvisionRequest, bool, context.Context) (*ProvisionResponse, error)
@@ -29,6 +38,10 @@ type OpenServiceBroker interface {
 	Unbind(ServiceInstance, BindInstance, string, bool, context.Context) (*UnbindResponse, error)
 	Update(uuid.UUID, *UpdateRequest, bool, context.Context) (*UpdateResponse, error)
 	LastOperation(uuid.UUID, *LastOperationRequest) (*LastOperationResponse, error)
-	GetServiceInstance(uuid.UUID) (*ServiceInstance, error)
+	// LastBindingOperation - Polls the state of an async bind or unbind.
+	LastBindingOperation(req *BindingLastOperationRequest) (*LastOperationResponse, error)
+	GetServiceInstance(uuid.UUID) (*ServiceInstanceResponse, error)
 	GetBindInstance(uuid.UUID) (*BindInstance, error)
+	// GetBinding - Fetches a previously created binding.
+	GetBinding(instanceID, bindingID uuid.UUID) (*GetBindingResponse, error)
 }
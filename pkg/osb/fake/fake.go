@@ -0,0 +1,241 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package fake provides an in-memory osb.OpenServiceBroker implementation
+// for unit-testing code that drives a broker, without standing up a real
+// HTTP server. Configure a Reaction on the fields below to control what
+// each method returns, then inspect the Actions recorded after the call.
+package fake
+
+import (
+	"context"
+	"sync"
+
+	"github.com/eriknelson/hydro/pkg/osb"
+	"github.com/pborman/uuid"
+)
+
+// Action records the arguments a FakeBroker method was called with, so
+// tests can assert on what the broker observed.
+type Action struct {
+	// Method is the OpenServiceBroker method name, e.g. "Provision".
+	Method string
+	// InstanceID is the service instance the action targeted, if any.
+	InstanceID uuid.UUID
+	// BindingID is the binding the action targeted, if any.
+	BindingID uuid.UUID
+	// AcceptsIncomplete is the value passed for async support, if any.
+	AcceptsIncomplete bool
+	// Request is the request object the caller passed in, if any.
+	Request interface{}
+	// Context is the context.Context the caller passed in, if any.
+	Context context.Context
+}
+
+// ProvisionReaction configures the canned response for Provision.
+type ProvisionReaction struct {
+	Response *osb.ProvisionResponse
+	Async    bool
+	Error    error
+}
+
+// DeprovisionReaction configures the canned response for Deprovision.
+type DeprovisionReaction struct {
+	Response *osb.DeprovisionResponse
+	Async    bool
+	Error    error
+}
+
+// BindReaction configures the canned response for Bind.
+type BindReaction struct {
+	Response *osb.BindResponse
+	Async    bool
+	Error    error
+}
+
+// UnbindReaction configures the canned response for Unbind.
+type UnbindReaction struct {
+	Response *osb.UnbindResponse
+	Async    bool
+	Error    error
+}
+
+// UpdateReaction configures the canned response for Update.
+type UpdateReaction struct {
+	Response *osb.UpdateResponse
+	Async    bool
+	Error    error
+}
+
+// FakeBroker is an in-memory osb.OpenServiceBroker for tests. The broker
+// returned by New answers every call with an empty, successful response;
+// set the Reaction fields to exercise error paths or async behavior. Use
+// New rather than a zero FakeBroker, whose nil Reaction responses are
+// dereferenced on success.
+type FakeBroker struct {
+	mu sync.Mutex
+
+	CatalogResponse *osb.CatalogResponse
+	CatalogError    error
+
+	ProvisionReaction   ProvisionReaction
+	DeprovisionReaction DeprovisionReaction
+	BindReaction        BindReaction
+	UnbindReaction      UnbindReaction
+	UpdateReaction      UpdateReaction
+
+	LastOperationResponse        *osb.LastOperationResponse
+	LastOperationError           error
+	LastBindingOperationResponse *osb.LastOperationResponse
+	LastBindingOperationError    error
+
+	GetServiceInstanceResponse *osb.ServiceInstanceResponse
+	GetServiceInstanceError    error
+	GetBindInstanceResponse    *osb.BindInstance
+	GetBindInstanceError       error
+	GetBindingResponse         *osb.GetBindingResponse
+	GetBindingError            error
+
+	// Actions records every call made against this broker, in order.
+	Actions []Action
+}
+
+// New returns a FakeBroker that answers every call with an empty,
+// successful, synchronous response.
+func New() *FakeBroker {
+	return &FakeBroker{
+		CatalogResponse:              &osb.CatalogResponse{},
+		ProvisionReaction:            ProvisionReaction{Response: &osb.ProvisionResponse{}},
+		DeprovisionReaction:          DeprovisionReaction{Response: &osb.DeprovisionResponse{}},
+		BindReaction:                 BindReaction{Response: &osb.BindResponse{}},
+		UnbindReaction:               UnbindReaction{Response: &osb.UnbindResponse{}},
+		UpdateReaction:               UpdateReaction{Response: &osb.UpdateResponse{}},
+		LastOperationResponse:        &osb.LastOperationResponse{State: osb.LastOperationStateSucceeded},
+		LastBindingOperationResponse: &osb.LastOperationResponse{State: osb.LastOperationStateSucceeded},
+		GetServiceInstanceResponse:   &osb.ServiceInstanceResponse{},
+		GetBindInstanceResponse:      &osb.BindInstance{},
+		GetBindingResponse:           &osb.GetBindingResponse{},
+	}
+}
+
+func (f *FakeBroker) record(a Action) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Actions = append(f.Actions, a)
+}
+
+// Catalog satisfies osb.OpenServiceBroker.
+func (f *FakeBroker) Catalog() (*osb.CatalogResponse, error) {
+	f.record(Action{Method: "Catalog"})
+	return f.CatalogResponse, f.CatalogError
+}
+
+// Provision satisfies osb.OpenServiceBroker.
+func (f *FakeBroker) Provision(id uuid.UUID, req *osb.ProvisionRequest, acceptsIncomplete bool, ctx context.Context) (*osb.ProvisionResponse, error) {
+	f.record(Action{Method: "Provision", InstanceID: id, Request: req, AcceptsIncomplete: acceptsIncomplete, Context: ctx})
+	r := f.ProvisionReaction
+	if r.Error != nil {
+		return nil, r.Error
+	}
+	resp := *r.Response
+	if r.Async {
+		resp.Operation = "provisioning"
+	}
+	return &resp, nil
+}
+
+// Deprovision satisfies osb.OpenServiceBroker.
+func (f *FakeBroker) Deprovision(instance osb.ServiceInstance, planID string, acceptsIncomplete bool, ctx context.Context) (*osb.DeprovisionResponse, error) {
+	f.record(Action{Method: "Deprovision", InstanceID: instance.ID, AcceptsIncomplete: acceptsIncomplete, Context: ctx})
+	r := f.DeprovisionReaction
+	if r.Error != nil {
+		return nil, r.Error
+	}
+	resp := *r.Response
+	if r.Async {
+		resp.Operation = "deprovisioning"
+	}
+	return &resp, nil
+}
+
+// Bind satisfies osb.OpenServiceBroker.
+func (f *FakeBroker) Bind(instance osb.ServiceInstance, bindingID uuid.UUID, req *osb.BindRequest, acceptsIncomplete bool, ctx context.Context) (*osb.BindResponse, bool, error) {
+	f.record(Action{Method: "Bind", InstanceID: instance.ID, BindingID: bindingID, Request: req, AcceptsIncomplete: acceptsIncomplete, Context: ctx})
+	r := f.BindReaction
+	if r.Error != nil {
+		return nil, false, r.Error
+	}
+	resp := *r.Response
+	return &resp, r.Async, nil
+}
+
+// Unbind satisfies osb.OpenServiceBroker.
+func (f *FakeBroker) Unbind(instance osb.ServiceInstance, binding osb.BindInstance, planID string, acceptsIncomplete bool, ctx context.Context) (*osb.UnbindResponse, error) {
+	f.record(Action{Method: "Unbind", InstanceID: instance.ID, BindingID: binding.ID, AcceptsIncomplete: acceptsIncomplete, Context: ctx})
+	r := f.UnbindReaction
+	if r.Error != nil {
+		return nil, r.Error
+	}
+	resp := *r.Response
+	if r.Async {
+		resp.Operation = "unbinding"
+	}
+	return &resp, nil
+}
+
+// Update satisfies osb.OpenServiceBroker.
+func (f *FakeBroker) Update(id uuid.UUID, req *osb.UpdateRequest, acceptsIncomplete bool, ctx context.Context) (*osb.UpdateResponse, error) {
+	f.record(Action{Method: "Update", InstanceID: id, Request: req, AcceptsIncomplete: acceptsIncomplete, Context: ctx})
+	r := f.UpdateReaction
+	if r.Error != nil {
+		return nil, r.Error
+	}
+	resp := *r.Response
+	if r.Async {
+		resp.Operation = "updating"
+	}
+	return &resp, nil
+}
+
+// LastOperation satisfies osb.OpenServiceBroker.
+func (f *FakeBroker) LastOperation(id uuid.UUID, req *osb.LastOperationRequest) (*osb.LastOperationResponse, error) {
+	f.record(Action{Method: "LastOperation", InstanceID: id, Request: req})
+	return f.LastOperationResponse, f.LastOperationError
+}
+
+// LastBindingOperation satisfies osb.OpenServiceBroker.
+func (f *FakeBroker) LastBindingOperation(req *osb.BindingLastOperationRequest) (*osb.LastOperationResponse, error) {
+	f.record(Action{Method: "LastBindingOperation", InstanceID: req.InstanceID, BindingID: req.BindingID, Request: req})
+	return f.LastBindingOperationResponse, f.LastBindingOperationError
+}
+
+// GetServiceInstance satisfies osb.OpenServiceBroker.
+func (f *FakeBroker) GetServiceInstance(id uuid.UUID) (*osb.ServiceInstanceResponse, error) {
+	f.record(Action{Method: "GetServiceInstance", InstanceID: id})
+	return f.GetServiceInstanceResponse, f.GetServiceInstanceError
+}
+
+// GetBindInstance satisfies osb.OpenServiceBroker.
+func (f *FakeBroker) GetBindInstance(id uuid.UUID) (*osb.BindInstance, error) {
+	f.record(Action{Method: "GetBindInstance", BindingID: id})
+	return f.GetBindInstanceResponse, f.GetBindInstanceError
+}
+
+// GetBinding satisfies osb.OpenServiceBroker.
+func (f *FakeBroker) GetBinding(instanceID, bindingID uuid.UUID) (*osb.GetBindingResponse, error) {
+	f.record(Action{Method: "GetBinding", InstanceID: instanceID, BindingID: bindingID})
+	return f.GetBindingResponse, f.GetBindingError
+}
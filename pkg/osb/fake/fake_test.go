@@ -0,0 +1,60 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package fake
+
+import (
+	"testing"
+
+	"github.com/eriknelson/hydro/pkg/osb"
+	"github.com/eriknelson/hydro/pkg/osb/osbtest"
+)
+
+func TestFakeBrokerConformsSync(t *testing.T) {
+	broker := New()
+	osbtest.Run(t, broker, osbtest.Fixture{})
+
+	var methods []string
+	for _, a := range broker.Actions {
+		methods = append(methods, a.Method)
+	}
+	if len(methods) == 0 {
+		t.Fatal("expected the conformance run to have recorded actions")
+	}
+}
+
+func TestFakeBrokerConformsAsync(t *testing.T) {
+	broker := New()
+	broker.ProvisionReaction.Async = true
+	broker.BindReaction.Async = true
+	broker.UnbindReaction.Async = true
+	broker.DeprovisionReaction.Async = true
+
+	osbtest.Run(t, broker, osbtest.Fixture{})
+}
+
+// TestFakeBrokerConformsUnbindPollsBindingOperation asserts that polling
+// after an async unbind hits LastBindingOperation, not the instance-level
+// LastOperation, by making the two report opposite terminal states: if
+// osbtest polled the wrong one, this run would fail.
+func TestFakeBrokerConformsUnbindPollsBindingOperation(t *testing.T) {
+	broker := New()
+	broker.UnbindReaction.Async = true
+	broker.LastOperationResponse = &osb.LastOperationResponse{State: osb.LastOperationStateFailed}
+	broker.LastBindingOperationResponse = &osb.LastOperationResponse{State: osb.LastOperationStateSucceeded}
+
+	osbtest.Run(t, broker, osbtest.Fixture{})
+}
@@ -0,0 +1,147 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package osb
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Platform values for OriginatingIdentity.Platform, per
+// https://github.com/openservicebrokerapi/servicebroker/blob/v2.14/spec.md#originating-identity
+const (
+	PlatformKubernetes   = "kubernetes"
+	PlatformCloudFoundry = "cloudfoundry"
+)
+
+// OriginatingIdentity - The platform-specific identity of the user that
+// triggered the request carrying this broker call, decoded from the
+// X-Broker-API-Originating-Identity header. Use KubernetesIdentity or
+// CloudFoundryIdentity to decode Value into the shape the named platform
+// sends.
+type OriginatingIdentity struct {
+	Platform string
+	Value    map[string]interface{}
+}
+
+// KubernetesIdentityValue - The decoded payload of a Kubernetes
+// OriginatingIdentity.
+type KubernetesIdentityValue struct {
+	Username string              `json:"username"`
+	UID      string              `json:"uid"`
+	Groups   []string            `json:"groups,omitempty"`
+	Extra    map[string][]string `json:"extra,omitempty"`
+}
+
+// KubernetesIdentity decodes Value as the Kubernetes platform payload.
+// Returns an error if Platform isn't "kubernetes".
+func (o *OriginatingIdentity) KubernetesIdentity() (*KubernetesIdentityValue, error) {
+	if o.Platform != PlatformKubernetes {
+		return nil, fmt.Errorf("originating identity is for platform %q, not %q", o.Platform, PlatformKubernetes)
+	}
+	var identity KubernetesIdentityValue
+	if err := decodeIdentityValue(o.Value, &identity); err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// CloudFoundryIdentityValue - The decoded payload of a Cloud Foundry
+// OriginatingIdentity.
+type CloudFoundryIdentityValue struct {
+	UserID string `json:"user_id"`
+}
+
+// CloudFoundryIdentity decodes Value as the Cloud Foundry platform payload.
+// Returns an error if Platform isn't "cloudfoundry".
+func (o *OriginatingIdentity) CloudFoundryIdentity() (*CloudFoundryIdentityValue, error) {
+	if o.Platform != PlatformCloudFoundry {
+		return nil, fmt.Errorf("originating identity is for platform %q, not %q", o.Platform, PlatformCloudFoundry)
+	}
+	var identity CloudFoundryIdentityValue
+	if err := decodeIdentityValue(o.Value, &identity); err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func decodeIdentityValue(value map[string]interface{}, into interface{}) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, into)
+}
+
+// ParseOriginatingIdentityHeader parses the raw value of the
+// X-Broker-API-Originating-Identity header: "<platform> <base64 JSON>".
+func ParseOriginatingIdentityHeader(header string) (*OriginatingIdentity, error) {
+	parts := strings.SplitN(header, " ", 2)
+	if len(parts) != 2 {
+		return nil, errors.New("originating identity header must be \"<platform> <base64 value>\"")
+	}
+	decoded, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("originating identity value is not valid base64: %v", err)
+	}
+	var value map[string]interface{}
+	if err := json.Unmarshal(decoded, &value); err != nil {
+		return nil, fmt.Errorf("originating identity value is not valid JSON: %v", err)
+	}
+	return &OriginatingIdentity{Platform: parts[0], Value: value}, nil
+}
+
+type contextKey int
+
+const (
+	originatingIdentityContextKey contextKey = iota
+	requestIdentityContextKey
+)
+
+// ContextWithOriginatingIdentity returns a copy of ctx carrying identity,
+// for handlers to stash the caller's identity before invoking a broker
+// method.
+func ContextWithOriginatingIdentity(ctx context.Context, identity *OriginatingIdentity) context.Context {
+	return context.WithValue(ctx, originatingIdentityContextKey, identity)
+}
+
+// OriginatingIdentityFromContext returns the OriginatingIdentity stashed in
+// ctx by ContextWithOriginatingIdentity, if any. Broker implementations use
+// this to enforce per-user policy, e.g. returning ErrorForbidden.
+func OriginatingIdentityFromContext(ctx context.Context) (*OriginatingIdentity, bool) {
+	identity, ok := ctx.Value(originatingIdentityContextKey).(*OriginatingIdentity)
+	return identity, ok
+}
+
+// ContextWithRequestIdentity returns a copy of ctx carrying requestIdentity,
+// for handlers to stash the platform-supplied X-Broker-API-Request-Identity
+// before invoking a broker method.
+func ContextWithRequestIdentity(ctx context.Context, requestIdentity string) context.Context {
+	return context.WithValue(ctx, requestIdentityContextKey, requestIdentity)
+}
+
+// RequestIdentityFromContext returns the request identity stashed in ctx by
+// ContextWithRequestIdentity, if any. Broker implementations use this to
+// de-duplicate retried requests.
+func RequestIdentityFromContext(ctx context.Context) (string, bool) {
+	requestIdentity, ok := ctx.Value(requestIdentityContextKey).(string)
+	return requestIdentity, ok
+}
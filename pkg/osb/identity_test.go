@@ -0,0 +1,77 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package osb
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+)
+
+func TestParseOriginatingIdentityHeaderKubernetes(t *testing.T) {
+	payload := base64.StdEncoding.EncodeToString([]byte(`{"username":"duke","uid":"abc-123","groups":["system:authenticated"]}`))
+	identity, err := ParseOriginatingIdentityHeader(PlatformKubernetes + " " + payload)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if identity.Platform != PlatformKubernetes {
+		t.Fatalf("expected platform %q, got %q", PlatformKubernetes, identity.Platform)
+	}
+
+	k8s, err := identity.KubernetesIdentity()
+	if err != nil {
+		t.Fatalf("unexpected error decoding kubernetes identity: %v", err)
+	}
+	if k8s.Username != "duke" || k8s.UID != "abc-123" || len(k8s.Groups) != 1 {
+		t.Fatalf("unexpected kubernetes identity: %+v", k8s)
+	}
+
+	if _, err := identity.CloudFoundryIdentity(); err == nil {
+		t.Fatal("expected error decoding a kubernetes identity as cloudfoundry")
+	}
+}
+
+func TestParseOriginatingIdentityHeaderMalformed(t *testing.T) {
+	if _, err := ParseOriginatingIdentityHeader("kubernetes"); err == nil {
+		t.Fatal("expected error for a header missing the base64 value")
+	}
+	if _, err := ParseOriginatingIdentityHeader("kubernetes not-base64!!!"); err == nil {
+		t.Fatal("expected error for a non-base64 value")
+	}
+}
+
+func TestOriginatingIdentityContextRoundTrip(t *testing.T) {
+	identity := &OriginatingIdentity{Platform: PlatformCloudFoundry, Value: map[string]interface{}{"user_id": "u-1"}}
+	ctx := ContextWithOriginatingIdentity(context.Background(), identity)
+
+	got, ok := OriginatingIdentityFromContext(ctx)
+	if !ok || got != identity {
+		t.Fatalf("expected to round-trip identity through context, got %+v, %v", got, ok)
+	}
+
+	if _, ok := OriginatingIdentityFromContext(context.Background()); ok {
+		t.Fatal("expected no identity on a bare context")
+	}
+}
+
+func TestRequestIdentityContextRoundTrip(t *testing.T) {
+	ctx := ContextWithRequestIdentity(context.Background(), "req-1")
+	got, ok := RequestIdentityFromContext(ctx)
+	if !ok || got != "req-1" {
+		t.Fatalf("expected to round-trip request identity through context, got %q, %v", got, ok)
+	}
+}
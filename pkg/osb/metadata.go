@@ -0,0 +1,216 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package osb
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// RequiredPermission - A capability a platform must support for a Service
+// to be usable, listed in Service.Requires.
+// based on https://github.com/openservicebrokerapi/servicebroker/blob/v2.14/spec.md#service-objects
+type RequiredPermission string
+
+const (
+	// PermissionRouteForwarding - The service requires permission to
+	// intercept application route traffic.
+	PermissionRouteForwarding RequiredPermission = "route_forwarding"
+	// PermissionSyslogDrain - The service requires permission to stream
+	// application syslog to a URL.
+	PermissionSyslogDrain RequiredPermission = "syslog_drain"
+	// PermissionVolumeMount - The service requires permission to mount a
+	// volume into an application.
+	PermissionVolumeMount RequiredPermission = "volume_mount"
+)
+
+// ValidateRequires checks that every entry in the Service's Requires slice
+// is a known RequiredPermission, so brokers don't silently ship typo'd
+// strings that Cloud Foundry/Kubernetes will reject.
+func (s *Service) ValidateRequires() error {
+	for _, r := range s.Requires {
+		switch RequiredPermission(r) {
+		case PermissionRouteForwarding, PermissionSyslogDrain, PermissionVolumeMount:
+		default:
+			return fmt.Errorf("service %q: %q is not a known required permission", s.ID, r)
+		}
+	}
+	return nil
+}
+
+// ServiceMetadata - The conventional metadata keys platforms render for a
+// Service, decoded from Service.Metadata. Extra carries any keys outside
+// this convention, so DecodeMetadata round-trips without loss.
+// based on https://github.com/openservicebrokerapi/servicebroker/blob/v2.14/spec.md#service-metadata-conventions
+type ServiceMetadata struct {
+	DisplayName         string
+	ImageURL            string
+	LongDescription     string
+	ProviderDisplayName string
+	DocumentationURL    string
+	SupportURL          string
+	Extra               map[string]interface{}
+}
+
+// MarshalJSON implements json.Marshaler, emitting the conventional keys
+// alongside any Extra keys as a flat object.
+func (m ServiceMetadata) MarshalJSON() ([]byte, error) {
+	out := cloneExtra(m.Extra)
+	setIfNotEmpty(out, "displayName", m.DisplayName)
+	setIfNotEmpty(out, "imageUrl", m.ImageURL)
+	setIfNotEmpty(out, "longDescription", m.LongDescription)
+	setIfNotEmpty(out, "providerDisplayName", m.ProviderDisplayName)
+	setIfNotEmpty(out, "documentationUrl", m.DocumentationURL)
+	setIfNotEmpty(out, "supportUrl", m.SupportURL)
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, extracting the conventional
+// keys and stashing everything else in Extra.
+func (m *ServiceMetadata) UnmarshalJSON(data []byte) error {
+	raw := map[string]interface{}{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	m.DisplayName = popString(raw, "displayName")
+	m.ImageURL = popString(raw, "imageUrl")
+	m.LongDescription = popString(raw, "longDescription")
+	m.ProviderDisplayName = popString(raw, "providerDisplayName")
+	m.DocumentationURL = popString(raw, "documentationUrl")
+	m.SupportURL = popString(raw, "supportUrl")
+	m.Extra = raw
+	return nil
+}
+
+// DecodeMetadata decodes Service.Metadata into a ServiceMetadata. A nil
+// Metadata decodes to the zero value.
+func (s *Service) DecodeMetadata() (*ServiceMetadata, error) {
+	var md ServiceMetadata
+	if err := decodeMetadata(s.Metadata, &md); err != nil {
+		return nil, fmt.Errorf("service %q: %v", s.ID, err)
+	}
+	return &md, nil
+}
+
+// PlanCost - A cost amount and billing unit associated with a Plan.
+// based on https://github.com/openservicebrokerapi/servicebroker/blob/v2.14/spec.md#plan-metadata-conventions
+type PlanCost struct {
+	Amount map[string]float64 `json:"amount"`
+	Unit   string             `json:"unit"`
+}
+
+// PlanMetadata - The conventional metadata keys platforms render for a
+// Plan, decoded from Plan.Metadata. Extra carries any keys outside this
+// convention, so DecodeMetadata round-trips without loss.
+// based on https://github.com/openservicebrokerapi/servicebroker/blob/v2.14/spec.md#plan-metadata-conventions
+type PlanMetadata struct {
+	DisplayName string
+	Bullets     []string
+	Costs       []PlanCost
+	Extra       map[string]interface{}
+}
+
+// MarshalJSON implements json.Marshaler, emitting the conventional keys
+// alongside any Extra keys as a flat object.
+func (m PlanMetadata) MarshalJSON() ([]byte, error) {
+	out := cloneExtra(m.Extra)
+	setIfNotEmpty(out, "displayName", m.DisplayName)
+	if len(m.Bullets) > 0 {
+		out["bullets"] = m.Bullets
+	}
+	if len(m.Costs) > 0 {
+		out["costs"] = m.Costs
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, extracting the conventional
+// keys and stashing everything else in Extra.
+func (m *PlanMetadata) UnmarshalJSON(data []byte) error {
+	raw := map[string]interface{}{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	m.DisplayName = popString(raw, "displayName")
+	if bullets, ok := raw["bullets"]; ok {
+		delete(raw, "bullets")
+		encoded, err := json.Marshal(bullets)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(encoded, &m.Bullets); err != nil {
+			return err
+		}
+	}
+	if costs, ok := raw["costs"]; ok {
+		delete(raw, "costs")
+		encoded, err := json.Marshal(costs)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(encoded, &m.Costs); err != nil {
+			return err
+		}
+	}
+	m.Extra = raw
+	return nil
+}
+
+// DecodeMetadata decodes Plan.Metadata into a PlanMetadata. A nil Metadata
+// decodes to the zero value.
+func (p *Plan) DecodeMetadata() (*PlanMetadata, error) {
+	var md PlanMetadata
+	if err := decodeMetadata(p.Metadata, &md); err != nil {
+		return nil, fmt.Errorf("plan %q: %v", p.ID, err)
+	}
+	return &md, nil
+}
+
+func decodeMetadata(raw map[string]interface{}, into json.Unmarshaler) error {
+	if raw == nil {
+		return nil
+	}
+	encoded, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	return into.UnmarshalJSON(encoded)
+}
+
+func cloneExtra(extra map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(extra))
+	for k, v := range extra {
+		out[k] = v
+	}
+	return out
+}
+
+func setIfNotEmpty(out map[string]interface{}, key, value string) {
+	if value != "" {
+		out[key] = value
+	}
+}
+
+func popString(raw map[string]interface{}, key string) string {
+	v, ok := raw[key]
+	if !ok {
+		return ""
+	}
+	delete(raw, key)
+	s, _ := v.(string)
+	return s
+}
@@ -0,0 +1,90 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package osb
+
+import "testing"
+
+func TestServiceDecodeMetadataRoundTrips(t *testing.T) {
+	svc := &Service{
+		ID: "svc-1",
+		Metadata: map[string]interface{}{
+			"displayName": "My Database",
+			"imageUrl":    "https://example.com/icon.png",
+			"custom":      "value",
+		},
+	}
+
+	md, err := svc.DecodeMetadata()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if md.DisplayName != "My Database" || md.ImageURL != "https://example.com/icon.png" {
+		t.Fatalf("unexpected decoded metadata: %+v", md)
+	}
+	if md.Extra["custom"] != "value" {
+		t.Fatalf("expected custom key to survive in Extra, got %+v", md.Extra)
+	}
+
+	encoded, err := md.MarshalJSON()
+	if err != nil {
+		t.Fatalf("unexpected error marshaling: %v", err)
+	}
+
+	var roundTripped ServiceMetadata
+	if err := roundTripped.UnmarshalJSON(encoded); err != nil {
+		t.Fatalf("unexpected error unmarshaling: %v", err)
+	}
+	if roundTripped.DisplayName != md.DisplayName || roundTripped.Extra["custom"] != "value" {
+		t.Fatalf("expected round-trip to preserve metadata, got %+v", roundTripped)
+	}
+}
+
+func TestPlanDecodeMetadataRoundTrips(t *testing.T) {
+	plan := &Plan{
+		ID: "plan-1",
+		Metadata: map[string]interface{}{
+			"displayName": "Standard",
+			"bullets":     []interface{}{"1 CPU", "1GB RAM"},
+			"costs": []interface{}{
+				map[string]interface{}{"amount": map[string]interface{}{"usd": 9.99}, "unit": "MONTHLY"},
+			},
+		},
+	}
+
+	md, err := plan.DecodeMetadata()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if md.DisplayName != "Standard" || len(md.Bullets) != 2 {
+		t.Fatalf("unexpected decoded metadata: %+v", md)
+	}
+	if len(md.Costs) != 1 || md.Costs[0].Unit != "MONTHLY" || md.Costs[0].Amount["usd"] != 9.99 {
+		t.Fatalf("unexpected decoded costs: %+v", md.Costs)
+	}
+}
+
+func TestServiceValidateRequires(t *testing.T) {
+	svc := &Service{ID: "svc-1", Requires: []string{string(PermissionVolumeMount)}}
+	if err := svc.ValidateRequires(); err != nil {
+		t.Fatalf("unexpected error for a known permission: %v", err)
+	}
+
+	svc.Requires = []string{"typo_mount"}
+	if err := svc.ValidateRequires(); err == nil {
+		t.Fatal("expected error for an unknown permission")
+	}
+}
@@ -0,0 +1,163 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package osbtest drives a scripted OSB conversation - catalog, provision,
+// poll last_operation, bind, get_binding, unbind, deprovision - against any
+// osb.OpenServiceBroker, failing the test if any call returns an error or
+// an async operation does not reach the succeeded state. It only drives the
+// success path: error cases such as duplicate provisions or calls against
+// missing instances are not exercised. Use it to smoke-test a broker
+// implementation without standing up a real HTTP server.
+package osbtest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/eriknelson/hydro/pkg/osb"
+	"github.com/pborman/uuid"
+)
+
+// Fixture supplies the request bodies the conformance run should use. Leave
+// fields empty to use sensible defaults.
+type Fixture struct {
+	ServiceID string
+	PlanID    string
+}
+
+func (f Fixture) withDefaults() Fixture {
+	if f.ServiceID == "" {
+		f.ServiceID = "service-1"
+	}
+	if f.PlanID == "" {
+		f.PlanID = "plan-1"
+	}
+	return f
+}
+
+// Run drives catalog -> provision -> poll last_operation -> bind ->
+// get_binding -> unbind -> deprovision against broker, failing t if any
+// step returns an error or an async operation does not succeed.
+func Run(t *testing.T, broker osb.OpenServiceBroker, fixture Fixture) {
+	t.Helper()
+	fixture = fixture.withDefaults()
+	ctx := context.Background()
+
+	if _, err := broker.Catalog(); err != nil {
+		t.Fatalf("catalog: %v", err)
+	}
+
+	instanceID := uuid.NewRandom()
+	provisionResp, err := broker.Provision(instanceID, &osb.ProvisionRequest{
+		ServiceID:         fixture.ServiceID,
+		PlanID:            fixture.PlanID,
+		AcceptsIncomplete: true,
+	}, true, ctx)
+	if err != nil {
+		t.Fatalf("provision: %v", err)
+	}
+
+	if provisionResp.Operation != "" {
+		pollUntilSucceeded(t, func() (*osb.LastOperationResponse, error) {
+			return broker.LastOperation(instanceID, &osb.LastOperationRequest{
+				ServiceID: fixture.ServiceID,
+				PlanID:    fixture.PlanID,
+				Operation: provisionResp.Operation,
+			})
+		})
+	}
+
+	bindingID := uuid.NewRandom()
+	bindResp, isAsync, err := broker.Bind(osb.ServiceInstance{ID: instanceID}, bindingID, &osb.BindRequest{
+		ServiceID: fixture.ServiceID,
+		PlanID:    fixture.PlanID,
+	}, true, ctx)
+	if err != nil {
+		t.Fatalf("bind: %v", err)
+	}
+	if isAsync {
+		pollUntilSucceeded(t, func() (*osb.LastOperationResponse, error) {
+			return broker.LastBindingOperation(&osb.BindingLastOperationRequest{
+				InstanceID: instanceID,
+				BindingID:  bindingID,
+				ServiceID:  fixture.ServiceID,
+				PlanID:     fixture.PlanID,
+				Operation:  bindResp.Operation,
+			})
+		})
+	}
+
+	if _, err := broker.GetBinding(instanceID, bindingID); err != nil {
+		t.Fatalf("get_binding: %v", err)
+	}
+
+	unbindResp, err := broker.Unbind(osb.ServiceInstance{ID: instanceID}, osb.BindInstance{ID: bindingID}, fixture.PlanID, true, ctx)
+	if err != nil {
+		t.Fatalf("unbind: %v", err)
+	}
+	if unbindResp.Operation != "" {
+		pollUntilSucceeded(t, func() (*osb.LastOperationResponse, error) {
+			return broker.LastBindingOperation(&osb.BindingLastOperationRequest{
+				InstanceID: instanceID,
+				BindingID:  bindingID,
+				ServiceID:  fixture.ServiceID,
+				PlanID:     fixture.PlanID,
+				Operation:  unbindResp.Operation,
+			})
+		})
+	}
+
+	deprovisionResp, err := broker.Deprovision(osb.ServiceInstance{ID: instanceID}, fixture.PlanID, true, ctx)
+	if err != nil {
+		t.Fatalf("deprovision: %v", err)
+	}
+	if deprovisionResp.Operation != "" {
+		pollUntilSucceeded(t, func() (*osb.LastOperationResponse, error) {
+			return broker.LastOperation(instanceID, &osb.LastOperationRequest{
+				ServiceID: fixture.ServiceID,
+				PlanID:    fixture.PlanID,
+				Operation: deprovisionResp.Operation,
+			})
+		})
+	}
+}
+
+// pollUntilSucceeded polls poll until it reports a terminal state, failing
+// t if the operation fails, poll itself errors, or the operation is still
+// in progress after maxAttempts polls. Callers are expected to run this
+// against in-memory fakes, which settle immediately, so polls are made
+// back to back with no backoff.
+func pollUntilSucceeded(t *testing.T, poll func() (*osb.LastOperationResponse, error)) {
+	t.Helper()
+	const maxAttempts = 10
+	for i := 0; i < maxAttempts; i++ {
+		resp, err := poll()
+		if err != nil {
+			t.Fatalf("last_operation: %v", err)
+		}
+		switch resp.State {
+		case osb.LastOperationStateSucceeded:
+			return
+		case osb.LastOperationStateFailed:
+			t.Fatalf("last_operation: operation failed: %s", resp.Description)
+		case osb.LastOperationStateInProgress:
+			continue
+		default:
+			t.Fatalf("last_operation: unexpected state %q", resp.State)
+		}
+	}
+	t.Fatalf("last_operation: did not succeed after %d attempts", maxAttempts)
+}
@@ -0,0 +1,216 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/eriknelson/hydro/pkg/osb"
+	"github.com/eriknelson/hydro/pkg/osb/fake"
+	"github.com/eriknelson/hydro/pkg/osb/osbtest"
+	"github.com/pborman/uuid"
+)
+
+// httpBrokerClient is a thin osb.OpenServiceBroker adapter over a real HTTP
+// server started by this package. It exists so osbtest.Run can drive the
+// actual wire format - UUID path variables, query parameters, JSON bodies,
+// 202 Accepted semantics - end-to-end, rather than calling handlers directly.
+type httpBrokerClient struct {
+	baseURL  string
+	client   *http.Client
+	username string
+	password string
+}
+
+func (c *httpBrokerClient) do(method, path string, query url.Values, body, out interface{}) (int, error) {
+	var reqBody io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		reqBody = bytes.NewReader(encoded)
+	}
+	target := c.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(method, target, reqBody)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set(BrokerAPIVersionHeader, "2.14")
+	req.SetBasicAuth(c.username, c.password)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	if resp.StatusCode >= 400 {
+		return resp.StatusCode, fmt.Errorf("%s %s: %d: %s", method, path, resp.StatusCode, string(data))
+	}
+	if out != nil && len(data) > 0 {
+		if err := json.Unmarshal(data, out); err != nil {
+			return resp.StatusCode, err
+		}
+	}
+	return resp.StatusCode, nil
+}
+
+func (c *httpBrokerClient) Catalog() (*osb.CatalogResponse, error) {
+	var resp osb.CatalogResponse
+	_, err := c.do(http.MethodGet, "/v2/catalog", nil, nil, &resp)
+	return &resp, err
+}
+
+func (c *httpBrokerClient) Provision(id uuid.UUID, req *osb.ProvisionRequest, acceptsIncomplete bool, ctx context.Context) (*osb.ProvisionResponse, error) {
+	var resp osb.ProvisionResponse
+	_, err := c.do(http.MethodPut, "/v2/service_instances/"+id.String(), acceptsIncompleteQuery(acceptsIncomplete), req, &resp)
+	return &resp, err
+}
+
+func (c *httpBrokerClient) Deprovision(instance osb.ServiceInstance, planID string, acceptsIncomplete bool, ctx context.Context) (*osb.DeprovisionResponse, error) {
+	q := acceptsIncompleteQuery(acceptsIncomplete)
+	q.Set("plan_id", planID)
+	var resp osb.DeprovisionResponse
+	_, err := c.do(http.MethodDelete, "/v2/service_instances/"+instance.ID.String(), q, nil, &resp)
+	return &resp, err
+}
+
+func (c *httpBrokerClient) Bind(instance osb.ServiceInstance, bindingID uuid.UUID, req *osb.BindRequest, acceptsIncomplete bool, ctx context.Context) (*osb.BindResponse, bool, error) {
+	var resp osb.BindResponse
+	path := "/v2/service_instances/" + instance.ID.String() + "/service_bindings/" + bindingID.String()
+	status, err := c.do(http.MethodPut, path, acceptsIncompleteQuery(acceptsIncomplete), req, &resp)
+	return &resp, status == http.StatusAccepted, err
+}
+
+func (c *httpBrokerClient) Unbind(instance osb.ServiceInstance, binding osb.BindInstance, planID string, acceptsIncomplete bool, ctx context.Context) (*osb.UnbindResponse, error) {
+	q := acceptsIncompleteQuery(acceptsIncomplete)
+	q.Set("plan_id", planID)
+	var resp osb.UnbindResponse
+	path := "/v2/service_instances/" + instance.ID.String() + "/service_bindings/" + binding.ID.String()
+	_, err := c.do(http.MethodDelete, path, q, nil, &resp)
+	return &resp, err
+}
+
+func (c *httpBrokerClient) Update(id uuid.UUID, req *osb.UpdateRequest, acceptsIncomplete bool, ctx context.Context) (*osb.UpdateResponse, error) {
+	var resp osb.UpdateResponse
+	_, err := c.do(http.MethodPatch, "/v2/service_instances/"+id.String(), acceptsIncompleteQuery(acceptsIncomplete), req, &resp)
+	return &resp, err
+}
+
+func (c *httpBrokerClient) LastOperation(id uuid.UUID, req *osb.LastOperationRequest) (*osb.LastOperationResponse, error) {
+	var resp osb.LastOperationResponse
+	path := "/v2/service_instances/" + id.String() + "/last_operation"
+	_, err := c.do(http.MethodGet, path, lastOperationQuery(req.ServiceID, req.PlanID, req.Operation), nil, &resp)
+	return &resp, err
+}
+
+func (c *httpBrokerClient) LastBindingOperation(req *osb.BindingLastOperationRequest) (*osb.LastOperationResponse, error) {
+	var resp osb.LastOperationResponse
+	path := "/v2/service_instances/" + req.InstanceID.String() + "/service_bindings/" + req.BindingID.String() + "/last_operation"
+	_, err := c.do(http.MethodGet, path, lastOperationQuery(req.ServiceID, req.PlanID, req.Operation), nil, &resp)
+	return &resp, err
+}
+
+func (c *httpBrokerClient) GetServiceInstance(id uuid.UUID) (*osb.ServiceInstanceResponse, error) {
+	var resp osb.ServiceInstanceResponse
+	_, err := c.do(http.MethodGet, "/v2/service_instances/"+id.String(), nil, nil, &resp)
+	return &resp, err
+}
+
+// GetBindInstance has no OSB wire endpoint - it's broker-internal
+// bookkeeping this package's handlers use to look up a binding before
+// unbinding it, not something a platform calls over HTTP.
+func (c *httpBrokerClient) GetBindInstance(uuid.UUID) (*osb.BindInstance, error) {
+	return nil, errors.New("GetBindInstance has no OSB wire endpoint")
+}
+
+func (c *httpBrokerClient) GetBinding(instanceID, bindingID uuid.UUID) (*osb.GetBindingResponse, error) {
+	var resp osb.GetBindingResponse
+	path := "/v2/service_instances/" + instanceID.String() + "/service_bindings/" + bindingID.String()
+	_, err := c.do(http.MethodGet, path, nil, nil, &resp)
+	return &resp, err
+}
+
+func acceptsIncompleteQuery(acceptsIncomplete bool) url.Values {
+	q := url.Values{}
+	if acceptsIncomplete {
+		q.Set("accepts_incomplete", "true")
+	}
+	return q
+}
+
+func lastOperationQuery(serviceID, planID, operation string) url.Values {
+	q := url.Values{}
+	if serviceID != "" {
+		q.Set("service_id", serviceID)
+	}
+	if planID != "" {
+		q.Set("plan_id", planID)
+	}
+	if operation != "" {
+		q.Set("operation", operation)
+	}
+	return q
+}
+
+// TestHTTPServerConformsOverTheWireSync and TestHTTPServerConformsOverTheWireAsync
+// run the osb/osbtest conformance script against a real httptest.Server
+// wrapping this package's handlers and an osb/fake broker, both
+// synchronously and with provision, bind, unbind and deprovision configured
+// to go async, so the actual JSON wire format this package emits/parses is
+// exercised end-to-end rather than just at the handler-unit level.
+func TestHTTPServerConformsOverTheWireSync(t *testing.T) {
+	broker := fake.New()
+	ts := httptest.NewServer(New(broker, Credentials{Username: "admin", Password: "password"}, nil))
+	defer ts.Close()
+
+	client := &httpBrokerClient{baseURL: ts.URL, client: ts.Client(), username: "admin", password: "password"}
+	osbtest.Run(t, client, osbtest.Fixture{})
+}
+
+func TestHTTPServerConformsOverTheWireAsync(t *testing.T) {
+	broker := fake.New()
+	broker.ProvisionReaction.Async = true
+	broker.BindReaction.Async = true
+	broker.UnbindReaction.Async = true
+	broker.DeprovisionReaction.Async = true
+	ts := httptest.NewServer(New(broker, Credentials{Username: "admin", Password: "password"}, nil))
+	defer ts.Close()
+
+	client := &httpBrokerClient{baseURL: ts.URL, client: ts.Client(), username: "admin", password: "password"}
+	osbtest.Run(t, client, osbtest.Fixture{})
+}
@@ -0,0 +1,89 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/eriknelson/hydro/pkg/osb"
+)
+
+// well-known error codes the spec requires brokers to emit verbatim in the
+// ErrorResponse.Error field.
+const (
+	errCodeAsyncRequired    = "AsyncRequired"
+	errCodeConcurrencyError = "ConcurrencyError"
+	errCodeMaintenanceInfo  = "MaintenanceInfoConflict"
+)
+
+// statusErrors maps the sentinel errors returned by an OpenServiceBroker
+// implementation onto the HTTP status and well-known error code the spec
+// requires for that condition.
+var statusErrors = map[error]struct {
+	status int
+	code   string
+}{
+	osb.ErrorAlreadyProvisioned:       {http.StatusConflict, ""},
+	osb.ErrorDuplicate:                {http.StatusConflict, ""},
+	osb.ErrorNotFound:                 {http.StatusNotFound, ""},
+	osb.ErrorBindingExists:            {http.StatusConflict, ""},
+	osb.ErrorProvisionInProgress:      {http.StatusUnprocessableEntity, errCodeConcurrencyError},
+	osb.ErrorDeprovisionInProgress:    {http.StatusUnprocessableEntity, errCodeConcurrencyError},
+	osb.ErrorUpdateInProgress:         {http.StatusUnprocessableEntity, errCodeConcurrencyError},
+	osb.ErrorPlanNotFound:             {http.StatusBadRequest, ""},
+	osb.ErrorParameterNotUpdatable:    {http.StatusBadRequest, ""},
+	osb.ErrorParameterNotFound:        {http.StatusBadRequest, ""},
+	osb.ErrorPlanUpdateNotPossible:    {http.StatusBadRequest, ""},
+	osb.ErrorForbidden:                {http.StatusForbidden, ""},
+	osb.MaintenanceInfoConflict:       {http.StatusUnprocessableEntity, errCodeMaintenanceInfo},
+	osb.ErrorVolumeMountsNotSupported: {http.StatusUnprocessableEntity, ""},
+}
+
+// writeError translates err into the broker HTTP error response the spec
+// expects. Errors the broker didn't declare as one of the package's
+// sentinels are reported as a generic 500.
+func writeError(w http.ResponseWriter, err error) {
+	if mapped, ok := statusErrors[err]; ok {
+		if mapped.code != "" {
+			writeJSON(w, mapped.status, osb.ErrorResponseV2{Error: mapped.code, Description: err.Error()})
+			return
+		}
+		writeJSON(w, mapped.status, osb.ErrorResponse{Description: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusInternalServerError, osb.ErrorResponse{Description: err.Error()})
+}
+
+// writeWellKnownError writes one of the handler-level well-known errors
+// (such as AsyncRequired) that aren't broker sentinel errors
+// but are raised by this package while enforcing the spec's request/response
+// contract.
+func writeWellKnownError(w http.ResponseWriter, status int, code, description string) {
+	writeJSON(w, status, struct {
+		Error       string `json:"error"`
+		Description string `json:"description"`
+	}{Error: code, Description: description})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	// Encoding errors here can't be recovered from; the status line and
+	// headers are already on the wire.
+	_ = json.NewEncoder(w).Encode(body)
+}
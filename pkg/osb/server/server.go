@@ -0,0 +1,470 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package server turns an osb.OpenServiceBroker implementation into a
+// compliant OSB v2.14 HTTP server: routing, header validation, basic auth
+// and sentinel-error-to-status-code translation.
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/eriknelson/hydro/pkg/osb"
+	"github.com/gorilla/mux"
+	"github.com/pborman/uuid"
+)
+
+// MinBrokerAPIVersion - Lowest X-Broker-API-Version this server accepts.
+// Requests from an older platform are rejected with a 412.
+const MinBrokerAPIVersion = "2.13"
+
+// BrokerAPIVersionHeader - Header a platform must set on every request to
+// declare the OSB API version it speaks.
+const BrokerAPIVersionHeader = "X-Broker-API-Version"
+
+// OriginatingIdentityHeader - Header carrying the base64-encoded, platform
+// specific identity of the user driving the request.
+const OriginatingIdentityHeader = "X-Broker-API-Originating-Identity"
+
+// RequestIdentityHeader - Header carrying a platform-supplied identifier
+// for the request, used by brokers to de-duplicate retries.
+const RequestIdentityHeader = "X-Broker-API-Request-Identity"
+
+// Credentials - HTTP basic auth credentials the platform must present.
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// New - Builds a http.Handler that serves broker on the full v2.14 OSB
+// route table, protected by HTTP basic auth.
+func New(broker osb.OpenServiceBroker, credentials Credentials, logger *log.Logger) http.Handler {
+	router := mux.NewRouter()
+	AttachRoutes(router, broker, logger)
+	return basicAuth(router, credentials)
+}
+
+// AttachRoutes - Registers the v2.14 OSB route table on router, dispatching
+// to broker. Use this instead of New when the routes need to live alongside
+// other routes on a shared router, or auth is handled by other middleware.
+func AttachRoutes(router *mux.Router, broker osb.OpenServiceBroker, logger *log.Logger) {
+	h := &handler{broker: broker, logger: logger}
+
+	router.HandleFunc("/v2/catalog", h.catalog).Methods(http.MethodGet)
+	router.HandleFunc("/v2/service_instances/{instance_id}", h.provision).Methods(http.MethodPut)
+	router.HandleFunc("/v2/service_instances/{instance_id}", h.deprovision).Methods(http.MethodDelete)
+	router.HandleFunc("/v2/service_instances/{instance_id}", h.update).Methods(http.MethodPatch)
+	router.HandleFunc("/v2/service_instances/{instance_id}", h.getServiceInstance).Methods(http.MethodGet)
+	router.HandleFunc("/v2/service_instances/{instance_id}/last_operation", h.lastOperation).Methods(http.MethodGet)
+	router.HandleFunc("/v2/service_instances/{instance_id}/service_bindings/{binding_id}", h.bind).Methods(http.MethodPut)
+	router.HandleFunc("/v2/service_instances/{instance_id}/service_bindings/{binding_id}", h.unbind).Methods(http.MethodDelete)
+	router.HandleFunc("/v2/service_instances/{instance_id}/service_bindings/{binding_id}", h.getBinding).Methods(http.MethodGet)
+	router.HandleFunc("/v2/service_instances/{instance_id}/service_bindings/{binding_id}/last_operation", h.lastBindingOperation).Methods(http.MethodGet)
+
+	router.Use(versionMiddleware)
+	router.Use(identityMiddleware)
+}
+
+type handler struct {
+	broker osb.OpenServiceBroker
+	logger *log.Logger
+}
+
+func (h *handler) catalog(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.broker.Catalog()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	for i := range resp.Services {
+		if err := resp.Services[i].ValidateRequires(); err != nil {
+			if h.logger != nil {
+				h.logger.Printf("catalog: %v", err)
+			}
+			writeJSON(w, http.StatusInternalServerError, osb.ErrorResponse{
+				Description: "broker catalog is invalid: " + err.Error(),
+			})
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *handler) provision(w http.ResponseWriter, r *http.Request) {
+	instanceID, ok := pathUUID(w, r, "instance_id")
+	if !ok {
+		return
+	}
+	var req osb.ProvisionRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	acceptsIncomplete := acceptsIncomplete(r)
+
+	resp, err := h.broker.Provision(instanceID, &req, acceptsIncomplete, r.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if resp.Operation != "" && !acceptsIncomplete {
+		writeWellKnownError(w, http.StatusUnprocessableEntity, errCodeAsyncRequired,
+			"This service plan requires client support for asynchronous service operations.")
+		return
+	}
+	status := http.StatusCreated
+	if resp.Operation != "" {
+		status = http.StatusAccepted
+	}
+	writeJSON(w, status, resp)
+}
+
+func (h *handler) deprovision(w http.ResponseWriter, r *http.Request) {
+	instanceID, ok := pathUUID(w, r, "instance_id")
+	if !ok {
+		return
+	}
+	acceptsIncomplete := acceptsIncomplete(r)
+	planID := r.URL.Query().Get("plan_id")
+
+	resp, err := h.broker.Deprovision(osb.ServiceInstance{ID: instanceID, PlanID: uuid.Parse(planID)}, planID, acceptsIncomplete, r.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if resp.Operation != "" && !acceptsIncomplete {
+		writeWellKnownError(w, http.StatusUnprocessableEntity, errCodeAsyncRequired,
+			"This service plan requires client support for asynchronous service operations.")
+		return
+	}
+	status := http.StatusOK
+	if resp.Operation != "" {
+		status = http.StatusAccepted
+	}
+	writeJSON(w, status, resp)
+}
+
+func (h *handler) update(w http.ResponseWriter, r *http.Request) {
+	instanceID, ok := pathUUID(w, r, "instance_id")
+	if !ok {
+		return
+	}
+	var req osb.UpdateRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	acceptsIncomplete := acceptsIncomplete(r)
+
+	resp, err := h.broker.Update(instanceID, &req, acceptsIncomplete, r.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if resp.Operation != "" && !acceptsIncomplete {
+		writeWellKnownError(w, http.StatusUnprocessableEntity, errCodeAsyncRequired,
+			"This service plan requires client support for asynchronous service operations.")
+		return
+	}
+	status := http.StatusOK
+	if resp.Operation != "" {
+		status = http.StatusAccepted
+	}
+	writeJSON(w, status, resp)
+}
+
+func (h *handler) getServiceInstance(w http.ResponseWriter, r *http.Request) {
+	instanceID, ok := pathUUID(w, r, "instance_id")
+	if !ok {
+		return
+	}
+	resp, err := h.broker.GetServiceInstance(instanceID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *handler) lastOperation(w http.ResponseWriter, r *http.Request) {
+	instanceID, ok := pathUUID(w, r, "instance_id")
+	if !ok {
+		return
+	}
+	req := &osb.LastOperationRequest{
+		ServiceID: r.URL.Query().Get("service_id"),
+		PlanID:    r.URL.Query().Get("plan_id"),
+		Operation: r.URL.Query().Get("operation"),
+	}
+	resp, err := h.broker.LastOperation(instanceID, req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *handler) bind(w http.ResponseWriter, r *http.Request) {
+	instanceID, ok := pathUUID(w, r, "instance_id")
+	if !ok {
+		return
+	}
+	bindingID, ok := pathUUID(w, r, "binding_id")
+	if !ok {
+		return
+	}
+	var req osb.BindRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	acceptsIncomplete := acceptsIncomplete(r)
+
+	resp, isAsync, err := h.broker.Bind(osb.ServiceInstance{ID: instanceID, PlanID: uuid.Parse(req.PlanID)}, bindingID, &req, acceptsIncomplete, r.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if isAsync && !acceptsIncomplete {
+		writeWellKnownError(w, http.StatusUnprocessableEntity, errCodeAsyncRequired,
+			"This service plan requires client support for asynchronous service operations.")
+		return
+	}
+	if len(resp.VolumeMounts) > 0 {
+		if err := h.validateVolumeMounts(req.ServiceID, resp.VolumeMounts); err != nil {
+			writeError(w, err)
+			return
+		}
+	}
+	status := http.StatusCreated
+	if isAsync {
+		status = http.StatusAccepted
+	}
+	writeJSON(w, status, resp)
+}
+
+// validateVolumeMounts rejects a bind response carrying volume mounts that
+// the service's catalog entry doesn't declare PermissionVolumeMount for, or
+// that are individually malformed.
+func (h *handler) validateVolumeMounts(serviceID string, mounts []osb.VolumeMount) error {
+	catalog, err := h.broker.Catalog()
+	if err != nil {
+		return err
+	}
+	requiresVolumeMount := false
+	for _, svc := range catalog.Services {
+		if svc.ID != serviceID {
+			continue
+		}
+		for _, r := range svc.Requires {
+			if osb.RequiredPermission(r) == osb.PermissionVolumeMount {
+				requiresVolumeMount = true
+			}
+		}
+	}
+	if !requiresVolumeMount {
+		return osb.ErrorVolumeMountsNotSupported
+	}
+	for _, mount := range mounts {
+		if err := mount.Validate(); err != nil {
+			return osb.ErrorVolumeMountsNotSupported
+		}
+	}
+	return nil
+}
+
+func (h *handler) unbind(w http.ResponseWriter, r *http.Request) {
+	instanceID, ok := pathUUID(w, r, "instance_id")
+	if !ok {
+		return
+	}
+	bindingID, ok := pathUUID(w, r, "binding_id")
+	if !ok {
+		return
+	}
+	bindInstance, err := h.broker.GetBindInstance(bindingID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	acceptsIncomplete := acceptsIncomplete(r)
+	planID := r.URL.Query().Get("plan_id")
+
+	resp, err := h.broker.Unbind(osb.ServiceInstance{ID: instanceID}, *bindInstance, planID, acceptsIncomplete, r.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if resp.Operation != "" && !acceptsIncomplete {
+		writeWellKnownError(w, http.StatusUnprocessableEntity, errCodeAsyncRequired,
+			"This service plan requires client support for asynchronous service operations.")
+		return
+	}
+	status := http.StatusOK
+	if resp.Operation != "" {
+		status = http.StatusAccepted
+	}
+	writeJSON(w, status, resp)
+}
+
+func (h *handler) getBinding(w http.ResponseWriter, r *http.Request) {
+	instanceID, ok := pathUUID(w, r, "instance_id")
+	if !ok {
+		return
+	}
+	bindingID, ok := pathUUID(w, r, "binding_id")
+	if !ok {
+		return
+	}
+	resp, err := h.broker.GetBinding(instanceID, bindingID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *handler) lastBindingOperation(w http.ResponseWriter, r *http.Request) {
+	instanceID, ok := pathUUID(w, r, "instance_id")
+	if !ok {
+		return
+	}
+	bindingID, ok := pathUUID(w, r, "binding_id")
+	if !ok {
+		return
+	}
+	req := &osb.BindingLastOperationRequest{
+		InstanceID: instanceID,
+		BindingID:  bindingID,
+		ServiceID:  r.URL.Query().Get("service_id"),
+		PlanID:     r.URL.Query().Get("plan_id"),
+		Operation:  r.URL.Query().Get("operation"),
+	}
+	resp, err := h.broker.LastBindingOperation(req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// pathUUID extracts and validates a UUID path variable, writing a 400 and
+// returning ok=false if it's missing or malformed.
+func pathUUID(w http.ResponseWriter, r *http.Request, name string) (uuid.UUID, bool) {
+	raw := mux.Vars(r)[name]
+	parsed := uuid.Parse(raw)
+	if parsed == nil {
+		writeJSON(w, http.StatusBadRequest, osb.ErrorResponse{Description: name + " is not a valid UUID"})
+		return nil, false
+	}
+	return parsed, true
+}
+
+func decodeBody(w http.ResponseWriter, r *http.Request, into interface{}) bool {
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(into); err != nil {
+		writeJSON(w, http.StatusBadRequest, osb.ErrorResponse{Description: "malformed request body: " + err.Error()})
+		return false
+	}
+	return true
+}
+
+func acceptsIncomplete(r *http.Request) bool {
+	return r.URL.Query().Get("accepts_incomplete") == "true"
+}
+
+func versionMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		version := r.Header.Get(BrokerAPIVersionHeader)
+		if version == "" {
+			writeJSON(w, http.StatusPreconditionFailed, osb.ErrorResponse{
+				Description: BrokerAPIVersionHeader + " header is required",
+			})
+			return
+		}
+		if !apiVersionAtLeast(version, MinBrokerAPIVersion) {
+			writeJSON(w, http.StatusPreconditionFailed, osb.ErrorResponse{
+				Description: BrokerAPIVersionHeader + " " + version + " is not supported; this broker requires at least " + MinBrokerAPIVersion,
+			})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// apiVersionAtLeast reports whether version is parseable as "major.minor"
+// and is >= min numerically, e.g. "2.9" < "2.13".
+func apiVersionAtLeast(version, min string) bool {
+	vMajor, vMinor, ok := parseAPIVersion(version)
+	if !ok {
+		return false
+	}
+	minMajor, minMinor, _ := parseAPIVersion(min)
+	if vMajor != minMajor {
+		return vMajor > minMajor
+	}
+	return vMinor >= minMinor
+}
+
+func parseAPIVersion(version string) (major, minor int, ok bool) {
+	parts := strings.SplitN(version, ".", 2)
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+	major, majErr := strconv.Atoi(parts[0])
+	minor, minErr := strconv.Atoi(parts[1])
+	if majErr != nil || minErr != nil {
+		return 0, 0, false
+	}
+	return major, minor, true
+}
+
+// identityMiddleware decodes the OriginatingIdentity and request identity
+// headers, if present, and stashes them on the request's context so broker
+// methods can observe them via osb.OriginatingIdentityFromContext and
+// osb.RequestIdentityFromContext.
+func identityMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if raw := r.Header.Get(OriginatingIdentityHeader); raw != "" {
+			identity, err := osb.ParseOriginatingIdentityHeader(raw)
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, osb.ErrorResponse{
+					Description: OriginatingIdentityHeader + ": " + err.Error(),
+				})
+				return
+			}
+			ctx = osb.ContextWithOriginatingIdentity(ctx, identity)
+		}
+		if requestIdentity := r.Header.Get(RequestIdentityHeader); requestIdentity != "" {
+			ctx = osb.ContextWithRequestIdentity(ctx, requestIdentity)
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func basicAuth(next http.Handler, credentials Credentials) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if !ok || username != credentials.Username || password != credentials.Password {
+			w.Header().Set("WWW-Authenticate", `Basic realm="osb"`)
+			writeJSON(w, http.StatusUnauthorized, osb.ErrorResponse{Description: "invalid credentials"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,203 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/eriknelson/hydro/pkg/osb"
+	"github.com/pborman/uuid"
+)
+
+// stubBroker is a minimal osb.OpenServiceBroker used only to exercise the
+// HTTP routing and header/auth enforcement in this package. A fuller fake
+// lives in osb/fake.
+type stubBroker struct {
+	catalog       *osb.CatalogResponse
+	provisionResp *osb.ProvisionResponse
+	provisionErr  error
+	bindResp      *osb.BindResponse
+}
+
+func (s *stubBroker) Catalog() (*osb.CatalogResponse, error) { return s.catalog, nil }
+func (s *stubBroker) Provision(uuid.UUID, *osb.ProvisionRequest, bool, context.Context) (*osb.ProvisionResponse, error) {
+	return s.provisionResp, s.provisionErr
+}
+func (s *stubBroker) Deprovision(osb.ServiceInstance, string, bool, context.Context) (*osb.DeprovisionResponse, error) {
+	return &osb.DeprovisionResponse{}, nil
+}
+func (s *stubBroker) Bind(osb.ServiceInstance, uuid.UUID, *osb.BindRequest, bool, context.Context) (*osb.BindResponse, bool, error) {
+	if s.bindResp != nil {
+		return s.bindResp, false, nil
+	}
+	return &osb.BindResponse{}, false, nil
+}
+func (s *stubBroker) Unbind(osb.ServiceInstance, osb.BindInstance, string, bool, context.Context) (*osb.UnbindResponse, error) {
+	return &osb.UnbindResponse{}, nil
+}
+func (s *stubBroker) Update(uuid.UUID, *osb.UpdateRequest, bool, context.Context) (*osb.UpdateResponse, error) {
+	return &osb.UpdateResponse{}, nil
+}
+func (s *stubBroker) LastOperation(uuid.UUID, *osb.LastOperationRequest) (*osb.LastOperationResponse, error) {
+	return &osb.LastOperationResponse{State: osb.LastOperationStateSucceeded}, nil
+}
+func (s *stubBroker) LastBindingOperation(*osb.BindingLastOperationRequest) (*osb.LastOperationResponse, error) {
+	return &osb.LastOperationResponse{State: osb.LastOperationStateSucceeded}, nil
+}
+func (s *stubBroker) GetServiceInstance(uuid.UUID) (*osb.ServiceInstanceResponse, error) {
+	return &osb.ServiceInstanceResponse{}, nil
+}
+func (s *stubBroker) GetBindInstance(uuid.UUID) (*osb.BindInstance, error) {
+	return &osb.BindInstance{}, nil
+}
+func (s *stubBroker) GetBinding(uuid.UUID, uuid.UUID) (*osb.GetBindingResponse, error) {
+	return &osb.GetBindingResponse{}, nil
+}
+
+func newTestServer(broker osb.OpenServiceBroker) http.Handler {
+	return New(broker, Credentials{Username: "admin", Password: "password"}, nil)
+}
+
+func doRequest(t *testing.T, h http.Handler, method, path string, auth bool, version bool) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, nil)
+	if auth {
+		req.SetBasicAuth("admin", "password")
+	}
+	if version {
+		req.Header.Set(BrokerAPIVersionHeader, "2.14")
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCatalogRequiresAuth(t *testing.T) {
+	h := newTestServer(&stubBroker{catalog: &osb.CatalogResponse{}})
+	rec := doRequest(t, h, http.MethodGet, "/v2/catalog", false, true)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without auth, got %d", rec.Code)
+	}
+}
+
+func TestCatalogRequiresAPIVersionHeader(t *testing.T) {
+	h := newTestServer(&stubBroker{catalog: &osb.CatalogResponse{}})
+	rec := doRequest(t, h, http.MethodGet, "/v2/catalog", true, false)
+	if rec.Code != http.StatusPreconditionFailed {
+		t.Fatalf("expected 412 without %s, got %d", BrokerAPIVersionHeader, rec.Code)
+	}
+}
+
+func TestCatalogRejectsAPIVersionBelowMinimum(t *testing.T) {
+	h := newTestServer(&stubBroker{catalog: &osb.CatalogResponse{}})
+	req := httptest.NewRequest(http.MethodGet, "/v2/catalog", nil)
+	req.SetBasicAuth("admin", "password")
+	req.Header.Set(BrokerAPIVersionHeader, "2.9")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusPreconditionFailed {
+		t.Fatalf("expected 412 for an API version below %s, got %d", MinBrokerAPIVersion, rec.Code)
+	}
+}
+
+func TestCatalogRejectsUnknownRequiresPermission(t *testing.T) {
+	h := newTestServer(&stubBroker{
+		catalog: &osb.CatalogResponse{Services: []osb.Service{{ID: "svc-1", Requires: []string{"typo_mount"}}}},
+	})
+	rec := doRequest(t, h, http.MethodGet, "/v2/catalog", true, true)
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500 for a catalog with an unknown required permission, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestCatalogOK(t *testing.T) {
+	h := newTestServer(&stubBroker{catalog: &osb.CatalogResponse{Services: []osb.Service{{Name: "db"}}}})
+	rec := doRequest(t, h, http.MethodGet, "/v2/catalog", true, true)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestProvisionAsyncRequiredWhenNotAccepted(t *testing.T) {
+	h := newTestServer(&stubBroker{provisionResp: &osb.ProvisionResponse{Operation: "provisioning"}})
+	req := httptest.NewRequest(http.MethodPut, "/v2/service_instances/"+uuid.NewRandom().String(), strings.NewReader(`{}`))
+	req.SetBasicAuth("admin", "password")
+	req.Header.Set(BrokerAPIVersionHeader, "2.14")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422 for an async provision without accepts_incomplete, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), `"error":"AsyncRequired"`) {
+		t.Fatalf("expected body to carry the AsyncRequired well-known error code, got %s", rec.Body.String())
+	}
+}
+
+func TestProvisionInProgressReturnsConcurrencyError(t *testing.T) {
+	h := newTestServer(&stubBroker{provisionErr: osb.ErrorProvisionInProgress})
+	req := httptest.NewRequest(http.MethodPut, "/v2/service_instances/"+uuid.NewRandom().String(), strings.NewReader(`{}`))
+	req.SetBasicAuth("admin", "password")
+	req.Header.Set(BrokerAPIVersionHeader, "2.14")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), `"error":"ConcurrencyError"`) {
+		t.Fatalf("expected body to carry the ConcurrencyError well-known error code, got %s", rec.Body.String())
+	}
+}
+
+func TestBindRejectsVolumeMountsWhenServiceDoesntRequireIt(t *testing.T) {
+	h := newTestServer(&stubBroker{
+		catalog: &osb.CatalogResponse{Services: []osb.Service{{ID: "svc-1"}}},
+		bindResp: &osb.BindResponse{
+			VolumeMounts: []osb.VolumeMount{{
+				Driver:       "nfsv3",
+				ContainerDir: "/data",
+				Mode:         osb.VolumeMountModeReadWrite,
+				DeviceType:   "shared",
+				Device:       osb.VolumeMountDevice{VolumeID: "vol-1"},
+			}},
+		},
+	})
+
+	instanceID, bindingID := uuid.NewRandom(), uuid.NewRandom()
+	path := "/v2/service_instances/" + instanceID.String() + "/service_bindings/" + bindingID.String()
+	req := httptest.NewRequest(http.MethodPut, path, strings.NewReader(`{"service_id":"svc-1","plan_id":"plan-1"}`))
+	req.SetBasicAuth("admin", "password")
+	req.Header.Set(BrokerAPIVersionHeader, "2.14")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422 when service doesn't declare volume_mount, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestProvisionRejectsMalformedInstanceID(t *testing.T) {
+	h := newTestServer(&stubBroker{})
+	rec := doRequest(t, h, http.MethodPut, "/v2/service_instances/not-a-uuid", true, true)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for malformed instance id, got %d", rec.Code)
+	}
+}
@@ -49,11 +49,26 @@ var (
 	ErrorPlanUpdateNotPossible = errors.New("plan update not possible")
 	// ErrorForbidden - Should be returned by broker handler if the user does not have sufficient permissions
 	ErrorForbidden = errors.New("User does not have sufficient permissions")
+	// MaintenanceInfoConflict - Error for when the maintenance_info passed in
+	// a provision/update request doesn't match what the broker's
+	// catalog currently advertises for the plan
+	MaintenanceInfoConflict = errors.New("maintenance info conflict")
+	// ErrorVolumeMountsNotSupported - Error for when a bind response would
+	// carry volume mounts but the plan can't satisfy the request
+	ErrorVolumeMountsNotSupported = errors.New("volume mounts not supported")
 )
 
 // Parameters - generic string to object or value parameter
 type Parameters map[string]interface{}
 
+// MaintenanceInfo - Describes the maintenance available for a plan, or the
+// maintenance a provision/update request should apply.
+// based on https://github.com/openservicebrokerapi/servicebroker/blob/v2.14/spec.md#maintenance-info-object
+type MaintenanceInfo struct {
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
 // Context - Determines the context in which the service is running
 type Context struct {
 	Platform  string `json:"platform"`
@@ -104,14 +119,15 @@ type Service struct {
 // Plan - Plan to be returned
 // based on https://github.com/openservicebrokerapi/servicebroker/blob/master/spec.md#plan-object
 type Plan struct {
-	ID          string                 `json:"id"`
-	Name        string                 `json:"name"`
-	Description string                 `json:"description"`
-	Metadata    map[string]interface{} `json:"metadata,omitempty"`
-	Free        bool                   `json:"free,omitempty"`
-	Bindable    bool                   `json:"bindable,omitempty"`
-	Schemas     Schema                 `json:"schemas,omitempty"`
-	UpdatesTo   []string               `json:"updates_to,omitempty"`
+	ID              string                 `json:"id"`
+	Name            string                 `json:"name"`
+	Description     string                 `json:"description"`
+	Metadata        map[string]interface{} `json:"metadata,omitempty"`
+	Free            bool                   `json:"free,omitempty"`
+	Bindable        bool                   `json:"bindable,omitempty"`
+	Schemas         Schema                 `json:"schemas,omitempty"`
+	UpdatesTo       []string               `json:"updates_to,omitempty"`
+	MaintenanceInfo *MaintenanceInfo       `json:"maintenance_info,omitempty"`
 }
 
 // ServiceInstanceSchema - Schema definitions for creating and updating a service instance.
@@ -146,13 +162,14 @@ type CatalogResponse struct {
 // ProvisionRequest - Request for provision
 // Defined here https://github.com/openservicebrokerapi/servicebroker/blob/v2.12/spec.md#request-2
 type ProvisionRequest struct {
-	OrganizationID    uuid.UUID  `json:"organization_guid"`
-	PlanID            string     `json:"plan_id"`
-	ServiceID         string     `json:"service_id"`
-	SpaceID           uuid.UUID  `json:"space_guid"`
-	Context           Context    `json:"context"`
-	Parameters        Parameters `json:"parameters,omitempty"`
-	AcceptsIncomplete bool       `json:"accepts_incomplete,omitempty"`
+	OrganizationID    uuid.UUID        `json:"organization_guid"`
+	PlanID            string           `json:"plan_id"`
+	ServiceID         string           `json:"service_id"`
+	SpaceID           uuid.UUID        `json:"space_guid"`
+	Context           Context          `json:"context"`
+	Parameters        Parameters       `json:"parameters,omitempty"`
+	MaintenanceInfo   *MaintenanceInfo `json:"maintenance_info,omitempty"`
+	AcceptsIncomplete bool             `json:"accepts_incomplete,omitempty"`
 }
 
 // ProvisionResponse - Response for provison
@@ -189,10 +206,20 @@ type BindResponse struct {
 	Credentials     map[string]interface{} `json:"credentials,omitempty"`
 	SyslogDrainURL  string                 `json:"syslog_drain_url,omitempty"`
 	RouteServiceURL string                 `json:"route_service_url,omitempty"`
-	VolumeMounts    []interface{}          `json:"volume_mounts,omitempty"`
+	VolumeMounts    []VolumeMount          `json:"volume_mounts,omitempty"`
 	Operation       string                 `json:"operation,omitempty"`
 }
 
+// GetBindingResponse - Response for fetching a previously created binding.
+// Defined here https://github.com/openservicebrokerapi/servicebroker/blob/v2.14/spec.md#response-7
+type GetBindingResponse struct {
+	Credentials     map[string]interface{} `json:"credentials,omitempty"`
+	SyslogDrainURL  string                 `json:"syslog_drain_url,omitempty"`
+	RouteServiceURL string                 `json:"route_service_url,omitempty"`
+	VolumeMounts    []VolumeMount          `json:"volume_mounts,omitempty"`
+	Parameters      Parameters             `json:"parameters,omitempty"`
+}
+
 // UnbindResponse - Response for unbinding
 // Defined here https://github.com/openservicebrokerapi/servicebroker/blob/v2.12/spec.md#response-5
 type UnbindResponse struct {
@@ -206,19 +233,22 @@ type UpdateRequest struct {
 	PlanID         string            `json:"plan_id,omitempty"`
 	Parameters     map[string]string `json:"parameters,omitempty"`
 	PreviousValues struct {
-		PlanID         string    `json:"plan_id,omitempty"`
-		ServiceID      string    `json:"service_id,omitempty"`
-		OrganizationID uuid.UUID `json:"organization_id,omitempty"`
-		SpaceID        uuid.UUID `json:"space_id,omitempty"`
+		PlanID          string           `json:"plan_id,omitempty"`
+		ServiceID       string           `json:"service_id,omitempty"`
+		OrganizationID  uuid.UUID        `json:"organization_id,omitempty"`
+		SpaceID         uuid.UUID        `json:"space_id,omitempty"`
+		MaintenanceInfo *MaintenanceInfo `json:"maintenance_info,omitempty"`
 	} `json:"previous_values,omitempty"`
-	Context           Context `json:"context"`
-	AcceptsIncomplete bool    `json:"accepts_incomplete,omitempty"`
+	Context           Context          `json:"context"`
+	MaintenanceInfo   *MaintenanceInfo `json:"maintenance_info,omitempty"`
+	AcceptsIncomplete bool             `json:"accepts_incomplete,omitempty"`
 }
 
 // UpdateResponse - Response for an update for a service instance.
-// Defined here https://github.com/openservicebrokerapi/servicebroker/blob/v2.12/spec.md#response-3
+// Defined here https://github.com/openservicebrokerapi/servicebroker/blob/v2.14/spec.md#response-3
 type UpdateResponse struct {
-	Operation string `json:"operation,omitempty"`
+	DashboardURL string `json:"dashboard_url,omitempty"`
+	Operation    string `json:"operation,omitempty"`
 }
 
 // LastOperationRequest - Request to obtain state information about an action that was taken
@@ -229,6 +259,17 @@ type LastOperationRequest struct {
 	Operation string `json:"operation"`
 }
 
+// BindingLastOperationRequest - Request to obtain state information about an
+// async bind or unbind that was taken against a particular binding.
+// Defined here https://github.com/openservicebrokerapi/servicebroker/blob/v2.14/spec.md#polling-last-operation-for-service-bindings
+type BindingLastOperationRequest struct {
+	InstanceID uuid.UUID `json:"instance_id"`
+	BindingID  uuid.UUID `json:"binding_id"`
+	ServiceID  string    `json:"service_id"`
+	PlanID     string    `json:"plan_id"`
+	Operation  string    `json:"operation"`
+}
+
 const (
 	// LastOperationStateInProgress - In Progress state for last operation.
 	LastOperationStateInProgress = "in progress"
@@ -239,13 +280,20 @@ const (
 )
 
 // LastOperationResponse - Response for the laster operation request.
-// Defined here https://github.com/openservicebrokerapi/servicebroker/blob/v2.12/spec.md#response-1
+// Defined here https://github.com/openservicebrokerapi/servicebroker/blob/v2.14/spec.md#response-1
 type LastOperationResponse struct {
 	State       string `json:"state"`
-	Description string             `json:"description,omitempty"`
+	Description string `json:"description,omitempty"`
+	// InstanceUsable - Whether the instance remains usable while an update
+	// is in progress. Only meaningful when State is in progress.
+	InstanceUsable *bool `json:"instance_usable,omitempty"`
+	// UpdateRepeatable - Whether the in-progress update can be repeated if
+	// it fails partway through. Only meaningful when State is in progress.
+	UpdateRepeatable *bool `json:"update_repeatable,omitempty"`
 }
 
-// ServiceInstanceResponse - The response for a get service instance request
+// ServiceInstanceResponse - The response for a get service instance request.
+// Defined here https://github.com/openservicebrokerapi/servicebroker/blob/v2.14/spec.md#response-8
 type ServiceInstanceResponse struct {
 	ServiceID    string     `json:"service_id"`
 	PlanID       string     `json:"plan_id"`
@@ -258,3 +306,14 @@ type ServiceInstanceResponse struct {
 type ErrorResponse struct {
 	Description string `json:"description"`
 }
+
+// ErrorResponseV2 - Error response for broker errors that need to convey
+// whether the affected instance remains usable and whether the triggering
+// update can be repeated, per v2.14's maintenance_info error semantics.
+// Defined here https://github.com/openservicebrokerapi/servicebroker/blob/v2.14/spec.md#broker-errors
+type ErrorResponseV2 struct {
+	Error            string `json:"error,omitempty"`
+	Description      string `json:"description"`
+	InstanceUsable   *bool  `json:"instance_usable,omitempty"`
+	UpdateRepeatable *bool  `json:"update_repeatable,omitempty"`
+}
@@ -0,0 +1,66 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package osb
+
+import "fmt"
+
+// VolumeMountMode - Whether an application can write to a VolumeMount.
+type VolumeMountMode string
+
+const (
+	// VolumeMountModeReadOnly - The application may only read from the mount.
+	VolumeMountModeReadOnly VolumeMountMode = "r"
+	// VolumeMountModeReadWrite - The application may read from and write to the mount.
+	VolumeMountModeReadWrite VolumeMountMode = "rw"
+)
+
+// VolumeMountDevice - The device a VolumeMount is backed by.
+type VolumeMountDevice struct {
+	VolumeID    string                 `json:"volume_id"`
+	MountConfig map[string]interface{} `json:"mount_config,omitempty"`
+}
+
+// VolumeMount - A volume (e.g. NFS, SMB) a bind response asks the platform
+// to mount into the bound application.
+// based on https://github.com/openservicebrokerapi/servicebroker/blob/v2.14/spec.md#volume-mounts-object
+type VolumeMount struct {
+	Driver       string            `json:"driver"`
+	ContainerDir string            `json:"container_dir"`
+	Mode         VolumeMountMode   `json:"mode"`
+	DeviceType   string            `json:"device_type"`
+	Device       VolumeMountDevice `json:"device"`
+}
+
+// Validate checks that the VolumeMount carries a Mode the spec recognizes
+// and the required fields are populated.
+func (v VolumeMount) Validate() error {
+	switch v.Mode {
+	case VolumeMountModeReadOnly, VolumeMountModeReadWrite:
+	default:
+		return fmt.Errorf("volume mount mode must be %q or %q, got %q", VolumeMountModeReadOnly, VolumeMountModeReadWrite, v.Mode)
+	}
+	if v.Driver == "" {
+		return fmt.Errorf("volume mount must set driver")
+	}
+	if v.ContainerDir == "" {
+		return fmt.Errorf("volume mount must set container_dir")
+	}
+	if v.Device.VolumeID == "" {
+		return fmt.Errorf("volume mount device must set volume_id")
+	}
+	return nil
+}
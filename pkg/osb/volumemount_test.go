@@ -0,0 +1,44 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package osb
+
+import "testing"
+
+func TestVolumeMountValidate(t *testing.T) {
+	valid := VolumeMount{
+		Driver:       "nfsv3",
+		ContainerDir: "/data",
+		Mode:         VolumeMountModeReadWrite,
+		DeviceType:   "shared",
+		Device:       VolumeMountDevice{VolumeID: "vol-1"},
+	}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("unexpected error for a well-formed mount: %v", err)
+	}
+
+	invalidMode := valid
+	invalidMode.Mode = "write"
+	if err := invalidMode.Validate(); err == nil {
+		t.Fatal("expected error for an invalid mode")
+	}
+
+	noVolumeID := valid
+	noVolumeID.Device.VolumeID = ""
+	if err := noVolumeID.Validate(); err == nil {
+		t.Fatal("expected error for a missing volume id")
+	}
+}